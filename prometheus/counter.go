@@ -124,6 +124,13 @@ type counter struct {
 
 	// now is for testing purposes, by default it's time.Now.
 	now func() time.Time
+
+	// noRoundingLossReport disables reporting to the registered
+	// RoundingLossObserver for this counter. It is only set on the
+	// bookkeeping counters kept internally by RoundingLossCollector, to
+	// break the cycle where reporting a drop would itself Add to a counter
+	// that can drop (and report) its own residuals.
+	noRoundingLossReport bool
 }
 
 // addWithRoundingErrorChecking adds the base and addend,
@@ -160,20 +167,28 @@ func (c *counter) Add(v float64) {
 			// because it causes a rounding error,
 			// it doesn't equal only when v is a quite large number or it's a float
 			u := uint64(v)
+			// the fractional part of v is discarded outright here: change only
+			// ever accumulates the integer part u, so this is lost regardless
+			// of which branch below actually commits.
+			frac := v - float64(u)
 			oldChange := atomic.LoadUint64(&c.change)
 			newF, isChangeSmall := addWithRoundingErrorChecking(math.Float64frombits(oldBits), float64(oldChange+u))
 
 			if isChangeSmall {
 				if atomic.CompareAndSwapUint64(&c.change, oldChange, oldChange+u) {
+					reportRoundingLoss(c, frac)
 					return
 				}
 				continue
 			}
 			newBits := math.Float64bits(newF)
 			if atomic.CompareAndSwapUint64(&c.valBits, oldBits, newBits) {
-				// todo: here we might lose some small values, but it's acceptable
-				// otherwise we have no way to avoid this using atomic
-				// mutex might be too heavy for our case here
+				// the addend we folded in was oldChange+u, but the float64
+				// addition above may not have represented all of it; report
+				// the residual instead of silently discarding it
+				addend := float64(oldChange + u)
+				lost := addend - (newF - math.Float64frombits(oldBits))
+				reportRoundingLoss(c, frac+lost)
 				atomic.StoreUint64(&c.change, 0)
 				return
 			}
@@ -379,6 +394,134 @@ func (v *CounterVec) MustCurryWith(labels Labels) *CounterVec {
 	return vec
 }
 
+// NewCompensatedCounter creates a new Counter based on the provided CounterOpts
+// that uses Neumaier (improved Kahan) compensated summation instead of the
+// valBits/change split used by NewCounter. This trades a little extra work per
+// Add call for not silently dropping the residual of additions that would
+// otherwise be lost to float64 rounding, at the cost of a second atomic
+// word that has to be kept in sync with the running sum. Prefer NewCounter for
+// hot paths that only ever call Inc or Add with exact integers; reach for this
+// constructor when a counter accumulates many small float64 values and the
+// accumulated rounding error would otherwise be observable.
+//
+// The returned implementation also implements ExemplarAdder. It is safe to
+// perform the corresponding type assertion.
+func NewCompensatedCounter(opts CounterOpts) Counter {
+	desc := NewDesc(
+		BuildFQName(opts.Namespace, opts.Subsystem, opts.Name),
+		opts.Help,
+		nil,
+		opts.ConstLabels,
+	)
+	if opts.now == nil {
+		opts.now = time.Now
+	}
+	result := &compensatedCounter{desc: desc, labelPairs: desc.constLabelPairs, now: opts.now}
+	result.init(result) // Init self-collection.
+	result.createdTs = timestamppb.New(opts.now())
+	return result
+}
+
+// compensatedCounter is a Counter implementation that tracks its value as a
+// running sum plus a compensation term, following the Neumaier variant of
+// Kahan summation. sum and comp must go first in the struct to guarantee
+// alignment for atomic operations. http://golang.org/pkg/sync/atomic/#pkg-note-BUG
+type compensatedCounter struct {
+	// sum holds the bits of the float64 running sum.
+	sum uint64
+	// comp holds the bits of the float64 compensation term, i.e. the part
+	// of past addends that didn't fit into sum.
+	comp uint64
+
+	selfCollector
+	desc *Desc
+
+	createdTs  *timestamppb.Timestamp
+	labelPairs []*dto.LabelPair
+	exemplar   atomic.Value // Containing nil or a *dto.Exemplar.
+
+	// now is for testing purposes, by default it's time.Now.
+	now func() time.Time
+}
+
+func (c *compensatedCounter) Desc() *Desc {
+	return c.desc
+}
+
+func (c *compensatedCounter) Add(v float64) {
+	if v < 0 {
+		panic(errors.New("counter cannot decrease in value"))
+	}
+
+	// Update the running sum first, remembering the addend it was combined
+	// with and the rounded result so the compensation term can be derived
+	// from the winning CAS below. Neumaier's variant picks the correction
+	// formula based on which operand has the larger magnitude, which is
+	// what makes it tolerate addends much smaller than the running sum
+	// (unlike plain Kahan summation).
+	var oldSum, t float64
+	for {
+		oldBits := atomic.LoadUint64(&c.sum)
+		oldSum = math.Float64frombits(oldBits)
+		t = oldSum + v
+		if atomic.CompareAndSwapUint64(&c.sum, oldBits, math.Float64bits(t)) {
+			break
+		}
+	}
+
+	var corr float64
+	if math.Abs(oldSum) >= math.Abs(v) {
+		corr = (oldSum - t) + v
+	} else {
+		corr = (v - t) + oldSum
+	}
+
+	for {
+		oldBits := atomic.LoadUint64(&c.comp)
+		newComp := math.Float64frombits(oldBits) + corr
+		if atomic.CompareAndSwapUint64(&c.comp, oldBits, math.Float64bits(newComp)) {
+			return
+		}
+	}
+}
+
+func (c *compensatedCounter) AddWithExemplar(v float64, e Labels) {
+	c.Add(v)
+	c.updateExemplar(v, e)
+}
+
+func (c *compensatedCounter) Inc() {
+	c.Add(1)
+}
+
+func (c *compensatedCounter) get() float64 {
+	sum := math.Float64frombits(atomic.LoadUint64(&c.sum))
+	comp := math.Float64frombits(atomic.LoadUint64(&c.comp))
+	return sum + comp
+}
+
+func (c *compensatedCounter) Write(out *dto.Metric) error {
+	// Read the Exemplar first and the value second. This is to avoid a race condition
+	// where users see an exemplar for a not-yet-existing observation.
+	var exemplar *dto.Exemplar
+	if e := c.exemplar.Load(); e != nil {
+		exemplar = e.(*dto.Exemplar)
+	}
+	val := c.get()
+	return populateMetric(CounterValue, val, c.labelPairs, exemplar, out, c.createdTs)
+}
+
+func (c *compensatedCounter) updateExemplar(v float64, l Labels) {
+	if l == nil {
+		return
+	}
+	e, err := newExemplar(v, c.now(), l)
+	if err != nil {
+		panic(err)
+	}
+	c.exemplar.Store(e)
+}
+
 // CounterFunc is a Counter whose value is determined at collect time by calling a
 // provided function.
 //
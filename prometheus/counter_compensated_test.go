@@ -0,0 +1,98 @@
+// Copyright 2014 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCompensatedCounterAddPrecision(t *testing.T) {
+	const n = 1e7
+
+	plain := NewCounter(CounterOpts{
+		Name: "plain",
+		Help: "help",
+	}).(*counter)
+	compensated := NewCompensatedCounter(CounterOpts{
+		Name: "compensated",
+		Help: "help",
+	}).(*compensatedCounter)
+
+	for i := 0; i < n; i++ {
+		plain.Add(0.1)
+		compensated.Add(0.1)
+	}
+
+	want := 0.1 * n
+	plainErr := math.Abs(plain.get() - want)
+	compensatedErr := math.Abs(compensated.get() - want)
+
+	if compensatedErr >= plainErr {
+		t.Errorf("compensated summation did not improve precision: plain error %g, compensated error %g", plainErr, compensatedErr)
+	}
+	// The compensated error should be orders of magnitude smaller than the
+	// plain one for this workload.
+	if compensatedErr > plainErr/1e3 {
+		t.Errorf("compensated summation error %g is not orders of magnitude smaller than plain error %g", compensatedErr, plainErr)
+	}
+}
+
+func BenchmarkCounterAdd(b *testing.B) {
+	c := NewCounter(CounterOpts{
+		Name: "benchmark_counter",
+		Help: "help",
+	}).(*counter)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Add(3.1415)
+	}
+}
+
+func BenchmarkCompensatedCounterAdd(b *testing.B) {
+	c := NewCompensatedCounter(CounterOpts{
+		Name: "benchmark_compensated_counter",
+		Help: "help",
+	}).(*compensatedCounter)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Add(3.1415)
+	}
+}
+
+func BenchmarkCounterInc(b *testing.B) {
+	c := NewCounter(CounterOpts{
+		Name: "benchmark_counter_inc",
+		Help: "help",
+	}).(*counter)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Inc()
+	}
+}
+
+func BenchmarkCompensatedCounterInc(b *testing.B) {
+	c := NewCompensatedCounter(CounterOpts{
+		Name: "benchmark_compensated_counter_inc",
+		Help: "help",
+	}).(*compensatedCounter)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		c.Inc()
+	}
+}
@@ -0,0 +1,141 @@
+// Copyright 2014 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"math"
+	"sync"
+	"sync/atomic"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+// RoundingLossObserver is implemented by types that want to be notified
+// whenever counter.Add discards part of an addend because folding it into
+// the counter's float64 accumulator still triggered a rounding error. See
+// SetRoundingLossObserver to register one process-wide.
+//
+// ObserveDrop may be called from arbitrary goroutines calling Add
+// concurrently, so implementations must be safe for concurrent use.
+type RoundingLossObserver interface {
+	// ObserveDrop reports that the Counter identified by desc and labels
+	// discarded lost, the part of an addend that could not be represented.
+	ObserveDrop(desc *Desc, lost float64, labels []*dto.LabelPair)
+}
+
+var roundingLossObserver atomic.Value // Contains a RoundingLossObserver, possibly nil.
+
+// SetRoundingLossObserver registers o as the process-wide RoundingLossObserver
+// notified by all Counters created by this package. Pass nil to disable
+// observation again. It is safe to call concurrently with Counter.Add.
+func SetRoundingLossObserver(o RoundingLossObserver) {
+	roundingLossObserver.Store(&o)
+}
+
+// getRoundingLossObserver returns the currently registered RoundingLossObserver,
+// or nil if none has been set (or it was disabled again).
+func getRoundingLossObserver() RoundingLossObserver {
+	v, _ := roundingLossObserver.Load().(*RoundingLossObserver)
+	if v == nil {
+		return nil
+	}
+	return *v
+}
+
+// reportRoundingLoss notifies the registered RoundingLossObserver, if any,
+// that c discarded lost. It is a no-op if lost is 0, no observer is set, or c
+// is itself one of the bookkeeping counters an observer uses to track drops
+// (otherwise a long enough run would make such a counter's own residuals
+// trigger ObserveDrop again, recursing without bound).
+func reportRoundingLoss(c *counter, lost float64) {
+	if lost == 0 || c.noRoundingLossReport {
+		return
+	}
+	if obs := getRoundingLossObserver(); obs != nil {
+		obs.ObserveDrop(c.desc, lost, c.labelPairs)
+	}
+}
+
+// RoundingLossCollector is a Collector that tracks, per fully-qualified
+// metric name, how much value has been dropped due to float64 rounding; all
+// series of one CounterVec share a single entry. Create one with
+// NewRoundingLossCollector.
+type RoundingLossCollector struct {
+	desc *Desc
+
+	mu   sync.Mutex
+	lost map[string]*counter // keyed by the dropping Counter's fqName.
+}
+
+// NewRoundingLossCollector returns a RoundingLossCollector that exports the
+// cumulative rounding loss of every Counter as
+// prometheus_counter_rounding_loss_total, labeled by the dropping Counter's
+// fully-qualified metric name. The returned collector also implements
+// RoundingLossObserver, so the usual setup is:
+//
+//	c := prometheus.NewRoundingLossCollector()
+//	prometheus.MustRegister(c)
+//	prometheus.SetRoundingLossObserver(c)
+func NewRoundingLossCollector() *RoundingLossCollector {
+	return &RoundingLossCollector{
+		desc: NewDesc(
+			"prometheus_counter_rounding_loss_total",
+			"Total value dropped by a Counter due to float64 rounding, labeled by the affected metric.",
+			[]string{"metric"},
+			nil,
+		),
+		lost: make(map[string]*counter),
+	}
+}
+
+// ObserveDrop implements RoundingLossObserver.
+func (c *RoundingLossCollector) ObserveDrop(desc *Desc, lost float64, _ []*dto.LabelPair) {
+	if lost == 0 {
+		return
+	}
+	c.bookkeepingCounter(desc.fqName).Add(math.Abs(lost))
+}
+
+// bookkeepingCounter returns the internal counter tracking drops for
+// metricName, creating it on first use. It never reports its own residuals
+// to the registered RoundingLossObserver: it is that observer's own
+// bookkeeping state, so letting it observe itself would recurse forever.
+func (c *RoundingLossCollector) bookkeepingCounter(metricName string) *counter {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if lc, ok := c.lost[metricName]; ok {
+		return lc
+	}
+	lc := &counter{
+		desc:                 c.desc,
+		labelPairs:           MakeLabelPairs(c.desc, []string{metricName}),
+		noRoundingLossReport: true,
+	}
+	c.lost[metricName] = lc
+	return lc
+}
+
+// Describe implements Collector.
+func (c *RoundingLossCollector) Describe(ch chan<- *Desc) {
+	ch <- c.desc
+}
+
+// Collect implements Collector.
+func (c *RoundingLossCollector) Collect(ch chan<- Metric) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, lc := range c.lost {
+		ch <- lc
+	}
+}
@@ -0,0 +1,126 @@
+// Copyright 2014 The Prometheus Authors
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+)
+
+func TestRoundingLossCollectorObservesDrops(t *testing.T) {
+	defer SetRoundingLossObserver(nil)
+
+	collector := NewRoundingLossCollector()
+	SetRoundingLossObserver(collector)
+
+	c := NewCounter(CounterOpts{
+		Name: "test_counter",
+		Help: "help",
+	})
+
+	// Force the slow path that folds change into valBits and may discard a
+	// residual: a huge base value followed by many small integer increments.
+	c.Add(1e17)
+	for i := 0; i < 1000; i++ {
+		c.Inc()
+	}
+	// One more Add to flush the pending change into valBits.
+	c.Add(1)
+
+	m := &dto.Metric{}
+	if err := c.Write(m); err != nil {
+		t.Fatal(err)
+	}
+
+	lostMetric, ok := collector.lost["test_counter"]
+	if !ok {
+		t.Fatal("expected a rounding loss entry for test_counter")
+	}
+	out := &dto.Metric{}
+	if err := lostMetric.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Counter.GetValue() <= 0 {
+		t.Errorf("expected rounding loss collector to observe a positive drop, got %v", out.Counter.GetValue())
+	}
+}
+
+func TestRoundingLossCollectorObservesFractionalAddDrops(t *testing.T) {
+	defer SetRoundingLossObserver(nil)
+
+	collector := NewRoundingLossCollector()
+	SetRoundingLossObserver(collector)
+
+	c := NewCounter(CounterOpts{
+		Name: "test_fractional_counter",
+		Help: "help",
+	})
+
+	// Once the base is large enough that adding 0.1 directly would be a
+	// no-op, the fractional part of every Add(0.1) is discarded outright
+	// (the change accumulator only ever tracks the integer part).
+	c.Add(1e17)
+	for i := 0; i < 1000; i++ {
+		c.Add(0.1)
+	}
+
+	lostMetric, ok := collector.lost["test_fractional_counter"]
+	if !ok {
+		t.Fatal("expected a rounding loss entry for test_fractional_counter")
+	}
+	out := &dto.Metric{}
+	if err := lostMetric.Write(out); err != nil {
+		t.Fatal(err)
+	}
+	if out.Counter.GetValue() <= 0 {
+		t.Errorf("expected rounding loss collector to observe a positive drop from fractional Add, got %v", out.Counter.GetValue())
+	}
+}
+
+func TestRoundingLossCollectorDoesNotRecurseOnItsOwnDrops(t *testing.T) {
+	defer SetRoundingLossObserver(nil)
+
+	collector := NewRoundingLossCollector()
+	SetRoundingLossObserver(collector)
+
+	const metricName = "test_recursive_metric"
+	desc := &Desc{fqName: metricName}
+
+	// Prime the internal bookkeeping counter's value high enough that a
+	// subsequent tiny residual would, on a normal Counter, hit the
+	// rounding-error branch in Add and try to report a drop of its own.
+	for i := 0; i < 10; i++ {
+		collector.ObserveDrop(desc, 1e6, nil)
+	}
+	// The residual of this Add is dropped by the internal counter; the
+	// noRoundingLossReport guard keeps it from being reported back to the
+	// collector as a drop of its own.
+	collector.ObserveDrop(desc, 1e-11, nil)
+
+	lc := collector.bookkeepingCounter(metricName)
+	if !lc.noRoundingLossReport {
+		t.Error("expected the internal bookkeeping counter to suppress rounding-loss reporting")
+	}
+	if got := lc.get(); got < 1e7 {
+		t.Errorf("expected bookkeeping counter to have accumulated drops, got %v", got)
+	}
+}
+
+func TestSetRoundingLossObserverNil(t *testing.T) {
+	SetRoundingLossObserver(nil)
+	if getRoundingLossObserver() != nil {
+		t.Error("expected no observer to be registered")
+	}
+}